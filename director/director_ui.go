@@ -24,6 +24,7 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pelicanplatform/pelican/param"
@@ -34,7 +35,9 @@ import (
 
 type (
 	patchServerRequest struct {
-		Disabled bool `json:"disabled"`
+		Disabled   bool   `json:"disabled"`
+		Note       string `json:"note"`
+		TTLSeconds int    `json:"ttlSeconds"` // 0 means the disablement does not auto-expire
 	}
 	listServerRequest struct {
 		ServerType string `form:"server_type"` // "cache" or "origin"
@@ -211,11 +214,14 @@ func queryOrigins(ctx *gin.Context) {
 
 // Disable or enable an origin/cache server to accept object transfer request
 func handleDisableServerToggle(ctx *gin.Context) {
-	serverUrl := ctx.Query("serverUrl")
-	if serverUrl == "" {
+	// Keyed by server name (matches listServers and the on-demand health
+	// test trigger), not URL, so a disablement always applies to the
+	// server an admin actually sees in the UI.
+	serverName := ctx.Query("name")
+	if serverName == "" {
 		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
 			Status: server_structs.RespFailed,
-			Msg:    "'serverUrl' is a required query parameter",
+			Msg:    "'name' is a required query parameter",
 		})
 		return
 	}
@@ -230,7 +236,8 @@ func handleDisableServerToggle(ctx *gin.Context) {
 	}
 
 	// You can't enable a server that's not disabled
-	if _, ok := disabledServers[serverUrl]; !req.Disabled && !ok {
+	hasRecord, rawReason := disabledRecordReason(serverName)
+	if !req.Disabled && !hasRecord {
 		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
 			Status: server_structs.RespFailed,
 			Msg:    "Can't enable a server that is not disabled or does not exist",
@@ -238,42 +245,83 @@ func handleDisableServerToggle(ctx *gin.Context) {
 		return
 	}
 
-	isDisabled, reason := isServerDisabled(serverUrl)
+	isDisabled, reason := isServerDisabled(serverName)
 	if isDisabled && req.Disabled {
 		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
 			Status: server_structs.RespFailed,
 			Msg:    fmt.Sprint("Can't disable a server that already has been disabled with reason: ", reason),
 		})
 		return
-	} else if !isDisabled && !req.Disabled {
+	} else if !isDisabled && !req.Disabled && rawReason != tempEnabled {
 		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
 			Status: server_structs.RespFailed,
 			Msg:    fmt.Sprint("Can't enable a server that already has been enabled with reason: ", reason),
 		})
 		return
 	}
-	disabledServersMutex.Lock()
-	defer disabledServersMutex.Unlock()
+
+	actor := web_ui.GetAuthenticatedUser(ctx)
 
 	if req.Disabled {
-		// If we previously temporarily allowed a server, we switch to permFiltered (reset)
-		if reason == tempEnabled {
-			disabledServers[serverUrl] = permDisabeld
-		} else {
-			disabledServers[serverUrl] = tempDisabled
+		// If we previously temporarily allowed a server, we switch to permDisabled (reset)
+		newReason := tempDisabled
+		if rawReason == tempEnabled {
+			newReason = permDisabeld
+		}
+		if err := setServerDisabled(serverName, newReason, req.Note, actor, time.Duration(req.TTLSeconds)*time.Second); err != nil {
+			ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    fmt.Sprintf("Failed to persist disablement: %v", err),
+			})
+			return
 		}
 	} else {
-		if reason == tempDisabled {
-			// For temporarily filtered server, allowing them by removing the server from the map
-			delete(disabledServers, serverUrl)
-		} else if reason == permDisabeld {
-			// For servers to filter from the config, temporarily allow the server
-			disabledServers[serverUrl] = tempEnabled
+		if rawReason == tempDisabled {
+			// For temporarily filtered server, allow it by removing its record entirely
+			if err := clearServerDisabled(serverName, actor); err != nil {
+				ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+					Status: server_structs.RespFailed,
+					Msg:    fmt.Sprintf("Failed to clear disablement: %v", err),
+				})
+				return
+			}
+		} else if rawReason == permDisabeld {
+			// For servers disabled from config, temporarily allow the server
+			if err := setServerTempEnabled(serverName, actor); err != nil {
+				ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+					Status: server_structs.RespFailed,
+					Msg:    fmt.Sprintf("Failed to temporarily enable server: %v", err),
+				})
+				return
+			}
+		} else if rawReason == tempEnabled {
+			// Already enabled; don't report success for a request that does
+			// nothing.
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Server is already enabled",
+			})
+			return
 		}
 	}
 	ctx.JSON(http.StatusOK, server_structs.SimpleApiResp{Status: server_structs.RespOK, Msg: "success"})
 }
 
+// handleListDisabledServers returns the full set of disabled-server
+// records, including reason, freeform note, who disabled it, and when it
+// will auto re-enable (if ever).
+func handleListDisabledServers(ctx *gin.Context) {
+	records, err := listDisabledServers()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("Failed to list disabled servers: %v", err),
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, records)
+}
+
 // Endpoint for director support contact information
 func handleDirectorContact(ctx *gin.Context) {
 	email := param.Director_SupportContactEmail.GetString()
@@ -282,14 +330,23 @@ func handleDirectorContact(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, supportContactRes{Email: email, Url: url})
 }
 
-func RegisterDirectorWebAPI(router *gin.RouterGroup) {
+func RegisterDirectorWebAPI(router *gin.RouterGroup) error {
+	if err := initDisabledServerDB(); err != nil {
+		return err
+	}
+
 	directorWebAPI := router.Group("/api/v1.0/director_ui")
+	directorWebAPI.Use(newInFlightLimiterMiddleware())
 	// Follow RESTful schema
 	{
 		directorWebAPI.GET("/servers", listServers)
 		directorWebAPI.PATCH("/servers", web_ui.AuthHandler, web_ui.AdminAuthHandler, handleDisableServerToggle)
-		directorWebAPI.GET("/servers/origins/stat/*path", web_ui.AuthHandler, queryOrigins)
-		directorWebAPI.HEAD("/servers/origins/stat/*path", web_ui.AuthHandler, queryOrigins)
+		directorWebAPI.GET("/servers/disabled", web_ui.AuthHandler, web_ui.AdminAuthHandler, handleListDisabledServers)
+		directorWebAPI.GET("/servers/origins/stat/*path", web_ui.AuthHandler, withTimeout(queryOrigins))
+		directorWebAPI.HEAD("/servers/origins/stat/*path", web_ui.AuthHandler, withTimeout(queryOrigins))
+		directorWebAPI.POST("/servers/origins/healthTest/:name", web_ui.AuthHandler, web_ui.AdminAuthHandler, withTimeout(handleOnDemandHealthTest))
 		directorWebAPI.GET("/contact", handleDirectorContact)
 	}
+
+	return nil
 }
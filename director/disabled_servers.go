@@ -0,0 +1,218 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pelicanplatform/pelican/database"
+	"github.com/pelicanplatform/pelican/param"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type disabledReason string
+
+const (
+	tempDisabled disabledReason = "tempDisabled"
+	permDisabeld disabledReason = "permDisabled"
+	tempEnabled  disabledReason = "tempEnabled"
+)
+
+// DisabledServer is the persisted record of a director-side server
+// disablement, replacing the previous process-local map so the state
+// survives a director restart and carries an audit trail. It's keyed by
+// ServerName, the same identity listServers and the on-demand health test
+// trigger use, so a disablement always applies to the server an admin
+// actually sees in the UI.
+type DisabledServer struct {
+	ServerName   string `gorm:"primaryKey"`
+	Reason       disabledReason
+	FreeformNote string
+	DisabledBy   string
+	DisabledAt   time.Time
+	ExpiresAt    *time.Time // nil means the disablement does not expire on its own
+}
+
+func (DisabledServer) TableName() string {
+	return "disabled_servers"
+}
+
+const disabledServerSweepInterval = time.Minute
+
+// initDisabledServerDB migrates the disabled_servers table and, on first
+// boot (empty table), seeds it from the legacy config-driven permDisabled
+// server list so existing deployments don't silently re-enable origins on
+// upgrade. It also starts the background goroutine that sweeps expired,
+// TTL'd disablements.
+func initDisabledServerDB() error {
+	if err := database.ServerDatabase.AutoMigrate(&DisabledServer{}); err != nil {
+		return errors.New("failed to migrate disabled_servers table: " + err.Error())
+	}
+
+	var count int64
+	if err := database.ServerDatabase.Model(&DisabledServer{}).Count(&count).Error; err != nil {
+		return errors.New("failed to count disabled_servers: " + err.Error())
+	}
+	if count == 0 {
+		seedDisabledServersFromConfig()
+	}
+
+	go sweepExpiredDisabledServers()
+	return nil
+}
+
+// seedDisabledServersFromConfig populates the persistent store from the
+// static Director.FilteredServers config list (server names) the very
+// first time the director boots with this table, preserving prior behavior
+// for servers that were previously filtered purely via config.
+func seedDisabledServersFromConfig() {
+	now := time.Now()
+	for _, serverName := range param.Director_FilteredServers.GetStringSlice() {
+		record := DisabledServer{
+			ServerName:   serverName,
+			Reason:       permDisabeld,
+			FreeformNote: "Seeded from Director.FilteredServers on first boot",
+			DisabledBy:   "system",
+			DisabledAt:   now,
+		}
+		if err := database.ServerDatabase.Create(&record).Error; err != nil {
+			log.Errorf("Failed to seed disabled server %s from config: %v", serverName, err)
+		}
+	}
+}
+
+// sweepExpiredDisabledServers periodically deletes disablements whose TTL
+// has elapsed, re-enabling the server.
+func sweepExpiredDisabledServers() {
+	ticker := time.NewTicker(disabledServerSweepInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		var expired []DisabledServer
+		if err := database.ServerDatabase.Where("expires_at IS NOT NULL AND expires_at < ?", time.Now()).Find(&expired).Error; err != nil {
+			log.Errorf("Failed to query expired disabled servers: %v", err)
+			continue
+		}
+		for _, record := range expired {
+			if err := database.ServerDatabase.Delete(&record).Error; err != nil {
+				log.Errorf("Failed to remove expired disablement for %s: %v", record.ServerName, err)
+				continue
+			}
+			auditDisabledServerTransition(record.ServerName, "auto-re-enabled (ttl expired)", "system", "")
+		}
+	}
+}
+
+// isServerDisabled reports whether serverName currently has an active
+// disablement record, and if so, the reason it was disabled.
+func isServerDisabled(serverName string) (bool, disabledReason) {
+	var record DisabledServer
+	err := database.ServerDatabase.Where("server_name = ?", serverName).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ""
+		}
+		log.Errorf("Failed to look up disabled server %s: %v", serverName, err)
+		return false, ""
+	}
+	if record.Reason == tempEnabled {
+		return false, record.Reason
+	}
+	return true, record.Reason
+}
+
+// disabledRecordReason returns the raw reason stored for serverName,
+// including tempEnabled, and whether a record exists at all. Unlike
+// isServerDisabled, a tempEnabled record is not masked out.
+func disabledRecordReason(serverName string) (bool, disabledReason) {
+	var record DisabledServer
+	err := database.ServerDatabase.Where("server_name = ?", serverName).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ""
+		}
+		log.Errorf("Failed to look up disabled server %s: %v", serverName, err)
+		return false, ""
+	}
+	return true, record.Reason
+}
+
+// setServerDisabled creates or updates the disablement record for
+// serverName. A zero ttl means the disablement does not auto-expire.
+func setServerDisabled(serverName string, reason disabledReason, note, disabledBy string, ttl time.Duration) error {
+	record := DisabledServer{
+		ServerName:   serverName,
+		Reason:       reason,
+		FreeformNote: note,
+		DisabledBy:   disabledBy,
+		DisabledAt:   time.Now(),
+	}
+	if ttl > 0 {
+		expiresAt := record.DisabledAt.Add(ttl)
+		record.ExpiresAt = &expiresAt
+	}
+	if err := database.ServerDatabase.Save(&record).Error; err != nil {
+		return err
+	}
+	auditDisabledServerTransition(serverName, string(reason), disabledBy, note)
+	return nil
+}
+
+// clearServerDisabled removes any disablement record for serverName,
+// fully re-enabling it.
+func clearServerDisabled(serverName, enabledBy string) error {
+	if err := database.ServerDatabase.Where("server_name = ?", serverName).Delete(&DisabledServer{}).Error; err != nil {
+		return err
+	}
+	auditDisabledServerTransition(serverName, "enabled", enabledBy, "")
+	return nil
+}
+
+// setServerTempEnabled marks a config-permDisabled server as temporarily
+// allowed, without losing the fact that config still wants it disabled.
+func setServerTempEnabled(serverName, enabledBy string) error {
+	if err := database.ServerDatabase.Model(&DisabledServer{}).Where("server_name = ?", serverName).Update("reason", tempEnabled).Error; err != nil {
+		return err
+	}
+	auditDisabledServerTransition(serverName, string(tempEnabled), enabledBy, "")
+	return nil
+}
+
+// listDisabledServers returns every disablement record, including ones
+// that are only temporarily re-enabled, for the director_ui admin view.
+func listDisabledServers() ([]DisabledServer, error) {
+	var records []DisabledServer
+	if err := database.ServerDatabase.Order("disabled_at desc").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// auditDisabledServerTransition logs a structured audit event for every
+// disable/enable transition so operators can reconstruct who disabled what
+// server and why.
+func auditDisabledServerTransition(serverName, transition, actor, note string) {
+	log.WithFields(log.Fields{
+		"serverName": serverName,
+		"transition": transition,
+		"actor":      actor,
+		"note":       note,
+	}).Info("Disabled server audit event")
+}
@@ -0,0 +1,87 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pelicanplatform/pelican/database"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupDisabledServerTestDB points database.ServerDatabase at a fresh
+// in-memory sqlite database for the duration of the test, so these tests
+// don't depend on (or pollute) a real deployment's database.
+func setupDisabledServerTestDB(t *testing.T) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&DisabledServer{}))
+
+	orig := database.ServerDatabase
+	database.ServerDatabase = db
+	t.Cleanup(func() { database.ServerDatabase = orig })
+}
+
+// TestDisabledServerKeyedByName is a regression test for the serverUrl vs.
+// server.Name key mismatch: a disablement set via the same identity
+// listServers looks servers up by (the server's name) must actually be
+// visible to isServerDisabled using that same name.
+func TestDisabledServerKeyedByName(t *testing.T) {
+	setupDisabledServerTestDB(t)
+
+	const name = "origin1.example.org"
+	require.NoError(t, setServerDisabled(name, tempDisabled, "maintenance", "admin", 0))
+
+	disabled, reason := isServerDisabled(name)
+	require.True(t, disabled)
+	require.Equal(t, tempDisabled, reason)
+}
+
+func TestClearServerDisabledReEnablesByName(t *testing.T) {
+	setupDisabledServerTestDB(t)
+
+	const name = "origin2.example.org"
+	require.NoError(t, setServerDisabled(name, tempDisabled, "", "admin", 0))
+	disabled, _ := isServerDisabled(name)
+	require.True(t, disabled)
+
+	require.NoError(t, clearServerDisabled(name, "admin"))
+	disabled, _ = isServerDisabled(name)
+	require.False(t, disabled)
+}
+
+// TestSweepQueryFindsExpiredDisabledServerByName checks that the query
+// sweepExpiredDisabledServers uses finds an expired disablement under its
+// server name. It does not run the sweep loop itself.
+func TestSweepQueryFindsExpiredDisabledServerByName(t *testing.T) {
+	setupDisabledServerTestDB(t)
+
+	const name = "origin3.example.org"
+	require.NoError(t, setServerDisabled(name, tempDisabled, "", "admin", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	var expired []DisabledServer
+	require.NoError(t, database.ServerDatabase.Where("expires_at IS NOT NULL AND expires_at < ?", time.Now()).Find(&expired).Error)
+	require.Len(t, expired, 1)
+	require.Equal(t, name, expired[0].ServerName)
+}
@@ -0,0 +1,197 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pelicanplatform/pelican/health_test_auth"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type (
+	onDemandHealthTestResponse struct {
+		server_structs.SimpleApiResp
+		ExecutionID int64 `json:"executionId"`
+	}
+
+	directorTestRequestBody struct {
+		Status       string `json:"status"`
+		Message      string `json:"message"`
+		Timestamp    string `json:"timestamp"`
+		Kind         string `json:"kind"`
+		DirectorName string `json:"directorName"`
+	}
+
+	directorTestReply struct {
+		ExecutionID int64 `json:"executionId"`
+	}
+)
+
+// handleOnDemandHealthTest triggers a director->origin health test against a
+// single named origin or cache outside of the normal cron cadence, and
+// returns the id of the resulting execution record so the caller can poll
+// for its outcome via the origin's /directorTest/executions/:id endpoint.
+func handleOnDemandHealthTest(ctx *gin.Context) {
+	name := ctx.Param("name")
+	if name == "" {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "'name' is a required path parameter",
+		})
+		return
+	}
+
+	var target *server_structs.ServerAd
+	for _, ad := range listAdvertisement([]server_structs.ServerType{server_structs.OriginType, server_structs.CacheType}) {
+		if ad.Name == name {
+			serverAd := ad.ServerAd
+			target = &serverAd
+			break
+		}
+	}
+	if target == nil {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("No origin or cache registered with name %q", name),
+		})
+		return
+	}
+
+	execID, err := runOnDemandDirectorTest(*target)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("Failed to run on-demand health test: %v", err),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, onDemandHealthTestResponse{
+		SimpleApiResp: server_structs.SimpleApiResp{Status: server_structs.RespOK, Msg: "success"},
+		ExecutionID:   execID,
+	})
+}
+
+// directorTestClient returns the HTTP client used to report director test
+// results to origins/caches, configured with a client certificate when
+// Director.DirectorTestAuth calls for mTLS so it can authenticate to peers
+// running in mtls/either mode.
+func directorTestClient(serverHostname string) (*http.Client, error) {
+	authType := health_test_auth.AuthType(param.Director_DirectorTestAuth.GetString())
+	if authType == "" {
+		authType = health_test_auth.AuthTypeBearer
+	}
+	if authType == health_test_auth.AuthTypeBearer {
+		return http.DefaultClient, nil
+	}
+
+	cfg := health_test_auth.NewTLSCfg(authType, param.Director_DirectorTestCABundle.GetString(), serverHostname)
+	tlsCfg, err := cfg.GetClientTLSConfig(param.Director_DirectorTestClientCert.GetString(), param.Director_DirectorTestClientKey.GetString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build mTLS client config: %w", err)
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}}, nil
+}
+
+// probeOriginHealth makes a real network round trip to ad's advertised
+// server URL right now, rather than fabricating a result or echoing
+// whatever the periodic cron last happened to observe. An on-demand test
+// exists so an operator can learn the server's actual, current state
+// immediately; reporting a cached or canned status instead would either
+// whitewash a server that's actually down, or (before the first cron
+// cycle runs) flag a perfectly healthy one as failing.
+func probeOriginHealth(ad server_structs.ServerAd) (status, message string) {
+	client, err := directorTestClient(ad.WebURL.Hostname())
+	if err != nil {
+		return "error", fmt.Sprintf("failed to build health probe client: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodHead, ad.URL.String(), nil)
+	if err != nil {
+		return "error", fmt.Sprintf("failed to build health probe request: %v", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "error", fmt.Sprintf("failed to reach %s: %v", ad.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return "error", fmt.Sprintf("%s responded with status %d", ad.Name, resp.StatusCode)
+	}
+	return "ok", "On-demand reachability probe succeeded"
+}
+
+// runOnDemandDirectorTest probes the target server's health right now and
+// reports that genuine result to its directorTest endpoint, mirroring the
+// payload the periodic health test cron sends, and returns the execution
+// id the origin assigned to it.
+func runOnDemandDirectorTest(ad server_structs.ServerAd) (int64, error) {
+	token, err := CreateDirectorTestReportToken(ad)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create director test report token: %w", err)
+	}
+
+	status, message := probeOriginHealth(ad)
+	body := directorTestRequestBody{
+		Status:    status,
+		Message:   message,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Kind:      "manual",
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal director test payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ad.WebURL.String()+"/api/v1.0/origin-api/directorTest", bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build director test request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client, err := directorTestClient(ad.WebURL.Hostname())
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach %s: %w", ad.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%s responded with status %d", ad.Name, resp.StatusCode)
+	}
+
+	reply := directorTestReply{}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return 0, fmt.Errorf("failed to decode director test reply: %w", err)
+	}
+	return reply.ExecutionID, nil
+}
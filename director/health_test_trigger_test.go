@@ -0,0 +1,79 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeOriginHealthReportsRealOutcome(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	healthyURL, err := url.Parse(healthy.URL)
+	require.NoError(t, err)
+
+	healthyAd := server_structs.ServerAd{}
+	healthyAd.Name = "healthy-origin"
+	healthyAd.URL = *healthyURL
+	healthyAd.WebURL = *healthyURL
+
+	status, _ := probeOriginHealth(healthyAd)
+	assert.Equal(t, "ok", status, "a reachable server should be reported ok, not a stale cached value")
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	failingURL, err := url.Parse(failing.URL)
+	require.NoError(t, err)
+
+	failingAd := server_structs.ServerAd{}
+	failingAd.Name = "failing-origin"
+	failingAd.URL = *failingURL
+	failingAd.WebURL = *failingURL
+
+	status, message := probeOriginHealth(failingAd)
+	assert.Equal(t, "error", status)
+	assert.Contains(t, message, "failing-origin")
+}
+
+func TestProbeOriginHealthReportsErrorWhenUnreachable(t *testing.T) {
+	unreachable, err := url.Parse("http://127.0.0.1:1")
+	require.NoError(t, err)
+
+	unreachableAd := server_structs.ServerAd{}
+	unreachableAd.Name = "unreachable-origin"
+	unreachableAd.URL = *unreachable
+	unreachableAd.WebURL = *unreachable
+
+	status, message := probeOriginHealth(unreachableAd)
+	assert.Equal(t, "error", status)
+	assert.NotEmpty(t, message)
+}
@@ -0,0 +1,238 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	inFlightRequestsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pelican_director_requests_in_flight",
+		Help: "Current number of director API requests occupying the in-flight request limiter",
+	})
+	rejectedRequestsCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_director_requests_rejected_total",
+		Help: "Total number of director API requests rejected because the in-flight request limit was exhausted",
+	})
+
+	// longRunningRequestRegexMutex guards longRunningRequestRegex and
+	// longRunningRequestRegexSrc, which isLongRunningRequest reads and
+	// (on a config change) writes from concurrent gin request goroutines.
+	longRunningRequestRegexMutex sync.RWMutex
+	// longRunningRequestRegex caches the last-compiled Director.LongRunningRequestRegex
+	// so we don't recompile it on every request.
+	longRunningRequestRegex    *regexp.Regexp
+	longRunningRequestRegexSrc string
+)
+
+// isLongRunningRequest reports whether the given method+path matches the
+// configured Director.LongRunningRequestRegex. Matching requests bypass the
+// in-flight semaphore; only routes registered through withTimeout also get
+// a timeout.
+func isLongRunningRequest(method, path string) bool {
+	pattern := param.Director_LongRunningRequestRegex.GetString()
+	if pattern == "" {
+		return false
+	}
+
+	longRunningRequestRegexMutex.RLock()
+	compiled, src := longRunningRequestRegex, longRunningRequestRegexSrc
+	longRunningRequestRegexMutex.RUnlock()
+
+	if compiled == nil || src != pattern {
+		newCompiled, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Warningf("Invalid Director.LongRunningRequestRegex %q: %v", pattern, err)
+			return false
+		}
+		longRunningRequestRegexMutex.Lock()
+		longRunningRequestRegex = newCompiled
+		longRunningRequestRegexSrc = pattern
+		longRunningRequestRegexMutex.Unlock()
+		compiled = newCompiled
+	}
+	return compiled.MatchString(method + " " + path)
+}
+
+// newInFlightLimiterMiddleware returns a gin middleware that caps the number
+// of concurrent non-long-running director API requests at
+// Director.MaxRequestsInFlight, rejecting additional requests with 429 and a
+// Retry-After header once the semaphore is exhausted. Requests matching
+// Director.LongRunningRequestRegex bypass the semaphore entirely; routes
+// expected to match should be registered through withTimeout instead so
+// they still can't run forever.
+func newInFlightLimiterMiddleware() gin.HandlerFunc {
+	maxInFlight := param.Director_MaxRequestsInFlight.GetInt()
+	if maxInFlight <= 0 {
+		return func(ctx *gin.Context) { ctx.Next() }
+	}
+	sem := make(chan struct{}, maxInFlight)
+
+	return func(ctx *gin.Context) {
+		if isLongRunningRequest(ctx.Request.Method, ctx.FullPath()) {
+			ctx.Next()
+			return
+		}
+
+		select {
+		case sem <- struct{}{}:
+			inFlightRequestsGauge.Inc()
+			defer func() {
+				<-sem
+				inFlightRequestsGauge.Dec()
+			}()
+			ctx.Next()
+		default:
+			rejectedRequestsCounter.Inc()
+			ctx.Header("Retry-After", strconv.Itoa(1))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Director is at its in-flight request limit; please retry shortly",
+			})
+		}
+	}
+}
+
+// timeoutResponseWriter wraps a gin.ResponseWriter so that once timeout()
+// has been called, further writes from a still-running handler are
+// silently dropped instead of racing with (or appending to) the timeout
+// body withTimeout has already sent on the same connection.
+type timeoutResponseWriter struct {
+	gin.ResponseWriter
+	mu       sync.Mutex
+	timedOut bool
+}
+
+// timeout marks w as timed out and, under the same lock, sends code and obj
+// as the JSON timeout response through the underlying writer, unless the
+// handler has already started writing its own response.
+func (w *timeoutResponseWriter) timeout(code int, obj any) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+	if w.ResponseWriter.Written() {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+	_ = render.JSON{Data: obj}.Render(w.ResponseWriter)
+}
+
+func (w *timeoutResponseWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutResponseWriter) WriteString(s string) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timeoutResponseWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timeoutResponseWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// withTimeout wraps handler using Director.LongRunningRequestTimeout, so
+// routes classified as long-running (and thus exempt from the in-flight
+// semaphore) can't run indefinitely: the request's context is cancelled
+// when the timeout elapses, so fan-out work that honors ctx.Done() (e.g.
+// queryOrigins) actually stops, and the guarded response writer ensures the
+// timeout body and the handler's own response can never both reach the
+// connection.
+func withTimeout(handler gin.HandlerFunc) gin.HandlerFunc {
+	timeout := param.Director_LongRunningRequestTimeout.GetDuration()
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	return withTimeoutDuration(handler, timeout)
+}
+
+// withTimeoutDuration is the testable core of withTimeout, split out so
+// tests can exercise the cancellation/guarded-writer behavior on a short
+// timeout without depending on Director.LongRunningRequestTimeout.
+func withTimeoutDuration(handler gin.HandlerFunc, timeout time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+		ctx.Request = ctx.Request.WithContext(timeoutCtx)
+
+		guarded := &timeoutResponseWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = guarded
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			handler(ctx)
+		}()
+
+		select {
+		case <-done:
+			return
+		case <-timeoutCtx.Done():
+			ctx.Abort()
+			guarded.timeout(http.StatusServiceUnavailable, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Request timed out",
+			})
+		}
+
+		// The handler goroutine is expected to notice ctx.Request.Context()
+		// is done and return promptly, but until it does, ctx is still live
+		// in its hands: gin pools and reuses *gin.Context between requests,
+		// so returning here without waiting could hand this same context to
+		// a brand-new request while the orphaned goroutine still reads and
+		// writes through it. Block until it actually exits.
+		<-done
+	}
+}
@@ -0,0 +1,142 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLongRunningRequestConcurrentAccess(t *testing.T) {
+	// Regression test for a data race on the package-level compiled-regex
+	// cache: many goroutines hitting the middleware concurrently must not
+	// trip the race detector.
+	viper.Set("Director.LongRunningRequestRegex", "^POST /api/v1.0/director_ui/servers/origins/healthTest/")
+	t.Cleanup(viper.Reset)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			isLongRunningRequest(http.MethodGet, "/api/v1.0/director_ui/servers")
+		}()
+	}
+	wg.Wait()
+}
+
+func TestInFlightLimiterMiddlewareRejectsOverCapacity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	viper.Set("Director.MaxRequestsInFlight", 1)
+	t.Cleanup(viper.Reset)
+	middleware := newInFlightLimiterMiddleware()
+
+	engine := gin.New()
+	release := make(chan struct{})
+	engine.Use(middleware)
+	engine.GET("/slow", func(ctx *gin.Context) {
+		<-release
+		ctx.Status(http.StatusOK)
+	})
+
+	firstDone := make(chan struct{})
+	go func() {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+		engine.ServeHTTP(w, req)
+		close(firstDone)
+	}()
+
+	// Give the first request a moment to occupy the single semaphore slot.
+	time.Sleep(20 * time.Millisecond)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	close(release)
+	<-firstDone
+}
+
+func TestWithTimeoutCancelsContextAndGuardsDoubleWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handlerReturned := make(chan struct{})
+	handler := func(ctx *gin.Context) {
+		defer close(handlerReturned)
+		select {
+		case <-ctx.Request.Context().Done():
+			// Expected: the timeout cancelled our context. Give withTimeout a
+			// moment to send its timeout response; a late write from the
+			// cancelled handler must not panic or corrupt that response.
+			time.Sleep(10 * time.Millisecond)
+			_, _ = ctx.Writer.Write([]byte("too late"))
+		case <-time.After(time.Second):
+			t.Error("handler's request context was never cancelled on timeout")
+		}
+	}
+
+	engine := gin.New()
+	engine.GET("/slow", withTimeoutDuration(handler, 20*time.Millisecond))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+	engine.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(t, w.Body.String(), "too late")
+
+	select {
+	case <-handlerReturned:
+	case <-time.After(time.Second):
+		t.Fatal("handler goroutine never observed cancellation")
+	}
+}
+
+func TestWithTimeoutDurationWaitsForOrphanedHandlerBeforeReturning(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handlerExited := int32(0)
+	handler := func(ctx *gin.Context) {
+		<-ctx.Request.Context().Done()
+		// Simulate a slow-to-notice handler: the middleware must not return
+		// (and let gin recycle ctx) until this goroutine actually finishes.
+		time.Sleep(50 * time.Millisecond)
+		atomic.StoreInt32(&handlerExited, 1)
+	}
+
+	engine := gin.New()
+	engine.GET("/slow", withTimeoutDuration(handler, 10*time.Millisecond))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&handlerExited), "middleware must not return while the handler goroutine is still running")
+}
@@ -0,0 +1,151 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+// Package health_test_auth provides the shared TLS configuration surface
+// used to authenticate director<->origin health-test callbacks, so both
+// sides of the connection (the origin's inbound listener and the
+// director's outbound test client) agree on how trust is established
+// without duplicating certificate-handling logic.
+package health_test_auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AuthType selects how a director<->origin health-test callback is
+// authenticated.
+type AuthType string
+
+const (
+	AuthTypeBearer AuthType = "bearer"
+	AuthTypeMTLS   AuthType = "mtls"
+	AuthTypeEither AuthType = "either"
+)
+
+// TLSCfg bundles the authentication mode for director health-test
+// callbacks together with the material needed to build a *tls.Config for
+// the mtls/either modes, and the hostname that a presented client
+// certificate's SAN/CN must match.
+type TLSCfg struct {
+	authType         AuthType
+	caBundlePath     string
+	expectedHostname string
+}
+
+// NewTLSCfg constructs a TLSCfg for the given auth type. caBundlePath and
+// expectedHostname are ignored (and may be empty) when authType is
+// AuthTypeBearer.
+func NewTLSCfg(authType AuthType, caBundlePath, expectedHostname string) *TLSCfg {
+	return &TLSCfg{
+		authType:         authType,
+		caBundlePath:     caBundlePath,
+		expectedHostname: expectedHostname,
+	}
+}
+
+// GetAuthType returns the configured authentication mode.
+func (c *TLSCfg) GetAuthType() AuthType {
+	return c.authType
+}
+
+// GetTLSConfig builds a *tls.Config that requests (AuthTypeEither) or
+// requires (AuthTypeMTLS) a client certificate verified against the
+// configured CA bundle. Callers in AuthTypeBearer mode don't need a
+// TLS config with client-cert verification at all, so this returns nil.
+func (c *TLSCfg) GetTLSConfig() (*tls.Config, error) {
+	if c.authType == AuthTypeBearer {
+		return nil, nil
+	}
+
+	pool := x509.NewCertPool()
+	pem, err := os.ReadFile(c.caBundlePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read mTLS CA bundle")
+	}
+	if ok := pool.AppendCertsFromPEM(pem); !ok {
+		return nil, errors.New("mTLS CA bundle did not contain any usable certificates")
+	}
+
+	clientAuth := tls.RequireAndVerifyClientCert
+	if c.authType == AuthTypeEither {
+		// Bearer-authenticated clients may not present a certificate at all.
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: clientAuth,
+	}, nil
+}
+
+// GetClientTLSConfig builds a *tls.Config for the director's outbound
+// health-test client: it presents certFile/keyFile as its own identity and
+// trusts the same CA bundle used to verify inbound callbacks, so a single
+// CA issues and is trusted by both ends of the connection. Returns nil for
+// AuthTypeBearer, which has no TLS material to configure.
+func (c *TLSCfg) GetClientTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	if c.authType == AuthTypeBearer {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load mTLS client certificate")
+	}
+
+	pool := x509.NewCertPool()
+	pem, err := os.ReadFile(c.caBundlePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read mTLS CA bundle")
+	}
+	if ok := pool.AppendCertsFromPEM(pem); !ok {
+		return nil, errors.New("mTLS CA bundle did not contain any usable certificates")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   c.expectedHostname,
+	}, nil
+}
+
+// VerifyPeerHostname checks that one of the leaf certificate's DNS SANs
+// (falling back to its CommonName for older certs) matches the director's
+// advertised hostname, pinning the presented identity to the expected peer
+// rather than merely trusting anyone the CA bundle signed.
+func (c *TLSCfg) VerifyPeerHostname(certs []*x509.Certificate) error {
+	if len(certs) == 0 {
+		return errors.New("no client certificate was presented")
+	}
+	leaf := certs[0]
+	if err := leaf.VerifyHostname(c.expectedHostname); err != nil {
+		// VerifyHostname ignores CommonName, so a cert with no DNS SANs is
+		// matched on its CommonName here.
+		if len(leaf.DNSNames) == 0 && leaf.Subject.CommonName != "" && strings.EqualFold(leaf.Subject.CommonName, c.expectedHostname) {
+			return nil
+		}
+		return fmt.Errorf("client certificate is not valid for hostname %q: %w", c.expectedHostname, err)
+	}
+	return nil
+}
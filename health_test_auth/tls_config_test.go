@@ -0,0 +1,80 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package health_test_auth
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCABundle is a self-signed cert, valid only as PEM material for
+// exercising AppendCertsFromPEM; it is not used to perform a handshake.
+const testCABundle = `-----BEGIN CERTIFICATE-----
+MIIBejCCAR+gAwIBAgIUYNSLG/o4Wwvw9P2vkpCXE/EYDUkwCgYIKoZIzj0EAwIw
+EjEQMA4GA1UEAwwHdGVzdC1jYTAeFw0yNjA3MjYyMjAxMTFaFw0zNjA3MjMyMjAx
+MTFaMBIxEDAOBgNVBAMMB3Rlc3QtY2EwWTATBgcqhkjOPQIBBggqhkjOPQMBBwNC
+AASJ8EOQuzHx+ckmJM11939DHAZzqZzx2Db8m7+w1oBxeZ9DmwTcdd2/b1tfoB87
+yNi2euthCQJ/LD3vmpQtB3RGo1MwUTAdBgNVHQ4EFgQUgJvclCqVBnmYO26CChLT
++nqhPqswHwYDVR0jBBgwFoAUgJvclCqVBnmYO26CChLT+nqhPqswDwYDVR0TAQH/
+BAUwAwEB/zAKBggqhkjOPQQDAgNJADBGAiEA9QMgwoanAPMFycTKx90kqxcIZ///
+Ka0B0hsFvkKTVlsCIQDFgmfKxApZ/nmf3CUHc+17WgNj2Qa+5A0HYpPQgdnHGg==
+-----END CERTIFICATE-----`
+
+func writeTempCABundle(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, []byte(testCABundle), 0o600))
+	return path
+}
+
+func TestGetTLSConfig_Bearer(t *testing.T) {
+	cfg := NewTLSCfg(AuthTypeBearer, "", "director.example.org")
+	tlsCfg, err := cfg.GetTLSConfig()
+	require.NoError(t, err)
+	assert.Nil(t, tlsCfg, "bearer mode should not need a TLS config")
+}
+
+func TestGetTLSConfig_MTLSRequiresClientCert(t *testing.T) {
+	caPath := writeTempCABundle(t)
+	cfg := NewTLSCfg(AuthTypeMTLS, caPath, "director.example.org")
+	tlsCfg, err := cfg.GetTLSConfig()
+	require.NoError(t, err)
+	require.NotNil(t, tlsCfg)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsCfg.ClientAuth)
+}
+
+func TestGetTLSConfig_EitherAllowsMissingClientCert(t *testing.T) {
+	caPath := writeTempCABundle(t)
+	cfg := NewTLSCfg(AuthTypeEither, caPath, "director.example.org")
+	tlsCfg, err := cfg.GetTLSConfig()
+	require.NoError(t, err)
+	require.NotNil(t, tlsCfg)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, tlsCfg.ClientAuth, "either mode must tolerate bearer-only clients")
+}
+
+func TestGetTLSConfig_MissingBundleErrors(t *testing.T) {
+	cfg := NewTLSCfg(AuthTypeMTLS, filepath.Join(t.TempDir(), "missing.pem"), "director.example.org")
+	_, err := cfg.GetTLSConfig()
+	assert.Error(t, err)
+}
@@ -0,0 +1,260 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package origin_ui
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/param"
+	log "github.com/sirupsen/logrus"
+)
+
+type healthState string
+
+const (
+	healthStateOK       healthState = "ok"
+	healthStateWarning  healthState = "warning"
+	healthStateCritical healthState = "critical"
+)
+
+const (
+	defaultDirectorTestWindowSize    = 10
+	defaultDirectorTestMissThresh    = 2 // K: consecutive misses before a director is considered down
+	defaultDirectorTestRecoverThresh = 3 // M: consecutive all-ok reports before warning->ok
+)
+
+// directorHealthRecord tracks the rolling window of test outcomes reported
+// by a single director, identified by the issuer/subject of its verified
+// bearer token (or the pinned hostname of its client certificate in mTLS
+// mode).
+type directorHealthRecord struct {
+	DirectorName      string    `json:"directorName"`
+	Window            []bool    `json:"-"`
+	ConsecutiveMisses int       `json:"consecutiveMisses"`
+	LastStatus        string    `json:"lastStatus"`
+	LastMessage       string    `json:"lastMessage"`
+	LastSeen          time.Time `json:"lastSeen"`
+	timer             *time.Timer
+}
+
+func (r *directorHealthRecord) isDown(missThreshold int) bool {
+	return r.ConsecutiveMisses >= missThreshold
+}
+
+// isFailing reports whether this director's most recent report(s) are
+// actively missing, even if it hasn't yet accumulated enough consecutive
+// misses to be considered fully down. A single error report shouldn't be
+// enough to declare a director down, but it also shouldn't be invisible to
+// the aggregate state until K of them pile up.
+func (r *directorHealthRecord) isFailing() bool {
+	return r.ConsecutiveMisses > 0
+}
+
+var (
+	directorHealthMutex sync.Mutex
+	directorHealth      = make(map[string]*directorHealthRecord)
+
+	aggregateState       = healthStateWarning // mirrors the old "Initializing, unknown status" default
+	aggregateRecoverHits int
+)
+
+type directorStatusResponse struct {
+	AggregateState healthState             `json:"aggregateState"`
+	Directors      []*directorHealthRecord `json:"directors"`
+}
+
+// directorTestWindowSize, directorTestMissThreshold, and
+// directorTestRecoverThreshold read the configurable N/K/M knobs, falling
+// back to sane defaults when unset.
+func directorTestWindowSize() int {
+	if n := param.Origin_DirectorTestWindowSize.GetInt(); n > 0 {
+		return n
+	}
+	return defaultDirectorTestWindowSize
+}
+
+func directorTestMissThreshold() int {
+	if k := param.Origin_DirectorTestMissThreshold.GetInt(); k > 0 {
+		return k
+	}
+	return defaultDirectorTestMissThresh
+}
+
+func directorTestRecoverThreshold() int {
+	if m := param.Origin_DirectorTestRecoveryThreshold.GetInt(); m > 0 {
+		return m
+	}
+	return defaultDirectorTestRecoverThresh
+}
+
+func directorTestTimeout() time.Duration {
+	if d := param.Origin_DirectorTestTimeout.GetDuration(); d > 0 {
+		return d
+	}
+	return directorTimeoutDuration
+}
+
+// getOrCreateDirectorRecord returns the health record for directorName,
+// creating it if this is the first time we've heard from it. It does not
+// start the record's timeout timer; resetDirectorRecordTimer does that.
+// Callers must hold directorHealthMutex.
+func getOrCreateDirectorRecord(directorName string) *directorHealthRecord {
+	rec, ok := directorHealth[directorName]
+	if !ok {
+		rec = &directorHealthRecord{DirectorName: directorName}
+		directorHealth[directorName] = rec
+	}
+	return rec
+}
+
+// recordDirectorOutcome folds a newly reported test outcome into
+// directorName's rolling window, resets its per-director timeout timer,
+// and recomputes the aggregate health state.
+func recordDirectorOutcome(directorName string, success bool, status, message string) {
+	directorHealthMutex.Lock()
+	rec := getOrCreateDirectorRecord(directorName)
+	rec.Window = append(rec.Window, success)
+	if len(rec.Window) > directorTestWindowSize() {
+		rec.Window = rec.Window[len(rec.Window)-directorTestWindowSize():]
+	}
+	if success {
+		rec.ConsecutiveMisses = 0
+	} else {
+		rec.ConsecutiveMisses++
+	}
+	rec.LastStatus = status
+	rec.LastMessage = message
+	rec.LastSeen = time.Now()
+	resetDirectorRecordTimer(directorName, rec)
+	directorHealthMutex.Unlock()
+
+	recomputeAggregateState()
+}
+
+// resetDirectorRecordTimer (re)starts directorName's per-director timeout
+// timer. If no report arrives before it fires, the director is treated as
+// a consecutive miss, same as an explicit "error" report, and the timer
+// re-arms itself so a director that stays silent keeps accruing misses
+// instead of being judged on a single timeout.
+func resetDirectorRecordTimer(directorName string, rec *directorHealthRecord) {
+	if rec.timer != nil {
+		rec.timer.Stop()
+	}
+	rec.timer = time.AfterFunc(directorTestTimeout(), func() {
+		directorHealthMutex.Lock()
+		r := getOrCreateDirectorRecord(directorName)
+		r.ConsecutiveMisses++
+		r.LastMessage = "No director test report received within the time limit"
+		resetDirectorRecordTimer(directorName, r)
+		directorHealthMutex.Unlock()
+		recomputeAggregateState()
+	})
+}
+
+// recomputeAggregateState derives the overall director-component health
+// from the per-director records and, if it changed, pushes it to the
+// metrics subsystem. A director is "down" once it has missed K
+// consecutive reports, but a director currently failing (its latest
+// report was a miss) counts toward warning immediately, even below K —
+// a single error report shouldn't be invisible just because it hasn't
+// piled up yet. The aggregate is critical when every known director is
+// down, warning when any director is failing (but not all are down),
+// and only returns to ok after M consecutive rounds where every
+// director is healthy (hysteresis).
+func recomputeAggregateState() {
+	missThreshold := directorTestMissThreshold()
+	recoverThreshold := directorTestRecoverThreshold()
+
+	directorHealthMutex.Lock()
+	total := len(directorHealth)
+	down := 0
+	failing := 0
+	for _, rec := range directorHealth {
+		if rec.isDown(missThreshold) {
+			down++
+		}
+		if rec.isFailing() {
+			failing++
+		}
+	}
+	directorHealthMutex.Unlock()
+
+	var raw healthState
+	switch {
+	case total == 0:
+		raw = healthStateWarning
+	case down == total:
+		raw = healthStateCritical
+	case failing > 0:
+		raw = healthStateWarning
+	default:
+		raw = healthStateOK
+	}
+
+	var newState healthState
+	if raw == healthStateOK {
+		if aggregateState == healthStateOK {
+			return
+		}
+		aggregateRecoverHits++
+		if aggregateRecoverHits >= recoverThreshold {
+			newState = healthStateOK
+			aggregateRecoverHits = 0
+		} else {
+			// Still within the hysteresis window; hold at warning rather
+			// than flapping straight back to ok.
+			newState = healthStateWarning
+		}
+	} else {
+		aggregateRecoverHits = 0
+		newState = raw
+	}
+
+	if newState == aggregateState {
+		return
+	}
+	aggregateState = newState
+	message := fmt.Sprintf("%d/%d known directors healthy", total-failing, total)
+	if err := metrics.SetComponentHealthStatus("director", string(aggregateState), message); err != nil {
+		log.Errorln("Failed to update director component health status:", err)
+	}
+}
+
+// directorTestStatus exposes the per-director breakdown behind the
+// aggregate health flag, for debugging multi-director deployments.
+func directorTestStatus(ctx *gin.Context) {
+	directorHealthMutex.Lock()
+	records := make([]*directorHealthRecord, 0, len(directorHealth))
+	for _, rec := range directorHealth {
+		recCopy := *rec
+		records = append(records, &recCopy)
+	}
+	state := aggregateState
+	directorHealthMutex.Unlock()
+
+	ctx.JSON(http.StatusOK, directorStatusResponse{
+		AggregateState: state,
+		Directors:      records,
+	})
+}
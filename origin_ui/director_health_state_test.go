@@ -0,0 +1,130 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package origin_ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resetDirectorHealthState clears all director health bookkeeping so tests
+// don't leak state into each other, and restores directorTimeoutDuration
+// (which individual tests may shrink to exercise the timeout timer quickly).
+func resetDirectorHealthState(t *testing.T) {
+	t.Helper()
+	origTimeout := directorTimeoutDuration
+	directorHealthMutex.Lock()
+	for _, rec := range directorHealth {
+		if rec.timer != nil {
+			rec.timer.Stop()
+		}
+	}
+	directorHealth = make(map[string]*directorHealthRecord)
+	aggregateState = healthStateWarning
+	aggregateRecoverHits = 0
+	directorHealthMutex.Unlock()
+	t.Cleanup(func() {
+		directorHealthMutex.Lock()
+		for _, rec := range directorHealth {
+			if rec.timer != nil {
+				rec.timer.Stop()
+			}
+		}
+		directorHealthMutex.Unlock()
+		directorTimeoutDuration = origTimeout
+	})
+}
+
+func TestRecomputeAggregateStateGoesCriticalAfterMisses(t *testing.T) {
+	resetDirectorHealthState(t)
+
+	for i := 0; i < defaultDirectorTestMissThresh; i++ {
+		recordDirectorOutcome("director-a", false, "error", "unreachable")
+	}
+
+	directorHealthMutex.Lock()
+	state := aggregateState
+	directorHealthMutex.Unlock()
+	assert.Equal(t, healthStateCritical, state, "the only known director missing K reports should make the aggregate critical")
+}
+
+func TestRecomputeAggregateStateRecoveryRequiresHysteresis(t *testing.T) {
+	resetDirectorHealthState(t)
+
+	for i := 0; i < defaultDirectorTestMissThresh; i++ {
+		recordDirectorOutcome("director-a", false, "error", "unreachable")
+	}
+	directorHealthMutex.Lock()
+	require.Equal(t, healthStateCritical, aggregateState)
+	directorHealthMutex.Unlock()
+
+	// A single good report shouldn't immediately flip back to ok.
+	recordDirectorOutcome("director-a", true, "ok", "")
+	directorHealthMutex.Lock()
+	state := aggregateState
+	directorHealthMutex.Unlock()
+	assert.Equal(t, healthStateWarning, state, "recovery should hold at warning until the recover threshold is met")
+
+	for i := 1; i < defaultDirectorTestRecoverThresh; i++ {
+		recordDirectorOutcome("director-a", true, "ok", "")
+	}
+	directorHealthMutex.Lock()
+	state = aggregateState
+	directorHealthMutex.Unlock()
+	assert.Equal(t, healthStateOK, state, "after M consecutive ok reports the aggregate should return to ok")
+}
+
+func TestRecomputeAggregateStateWarnsOnSingleErrorReport(t *testing.T) {
+	resetDirectorHealthState(t)
+
+	recordDirectorOutcome("director-a", true, "ok", "")
+	recordDirectorOutcome("director-b", true, "ok", "")
+
+	// director-b's very first error shouldn't be invisible just because it
+	// hasn't reached the K-miss threshold yet: with one director still ok
+	// and one actively failing, the aggregate should be warning, not ok.
+	recordDirectorOutcome("director-b", false, "error", "unreachable")
+
+	directorHealthMutex.Lock()
+	state := aggregateState
+	misses := directorHealth["director-b"].ConsecutiveMisses
+	directorHealthMutex.Unlock()
+	require.Less(t, misses, defaultDirectorTestMissThresh, "test assumes a single miss stays below K")
+	assert.Equal(t, healthStateWarning, state, "a single error report from one director among otherwise-healthy directors should surface as warning")
+}
+
+func TestResetDirectorRecordTimerReArmsOnSilence(t *testing.T) {
+	resetDirectorHealthState(t)
+	directorTimeoutDuration = 20 * time.Millisecond
+
+	recordDirectorOutcome("director-silent", true, "ok", "")
+
+	// Wait long enough for the timeout timer to fire multiple times. With the
+	// old one-shot time.AfterFunc, ConsecutiveMisses would get stuck at 1
+	// regardless of how long the director stays silent.
+	require.Eventually(t, func() bool {
+		directorHealthMutex.Lock()
+		defer directorHealthMutex.Unlock()
+		rec, ok := directorHealth["director-silent"]
+		return ok && rec.ConsecutiveMisses >= 2
+	}, time.Second, 5*time.Millisecond, "a continuously silent director should keep accruing misses past 1")
+}
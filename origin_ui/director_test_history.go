@@ -0,0 +1,187 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package origin_ui
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type (
+	// directorTestExecutionKind distinguishes a director test that was launched
+	// by the director's periodic cron from one an admin requested on demand.
+	directorTestExecutionKind string
+
+	// directorTestExecution is a single record of a director->origin health
+	// test, kept around so operators can correlate an outage with concrete
+	// test failures instead of a single health flag.
+	directorTestExecution struct {
+		ID           int64                     `json:"id"`
+		Kind         directorTestExecutionKind `json:"kind"`
+		DirectorName string                    `json:"directorName"`
+		StartedAt    time.Time                 `json:"startedAt"`
+		FinishedAt   time.Time                 `json:"finishedAt"`
+		Status       string                    `json:"status"`
+		Message      string                    `json:"message"`
+	}
+
+	listExecutionsRequest struct {
+		Kind   string `form:"kind"`
+		Since  string `form:"since"` // RFC3339 timestamp
+		Limit  int    `form:"limit"`
+		Offset int    `form:"offset"`
+	}
+
+	listExecutionsResponse struct {
+		Executions []directorTestExecution `json:"executions"`
+		Total      int                     `json:"total"`
+	}
+)
+
+const (
+	executionKindScheduled directorTestExecutionKind = "scheduled"
+	executionKindManual    directorTestExecutionKind = "manual"
+)
+
+const defaultExecutionHistorySize = 200
+
+var (
+	executionsMutex sync.RWMutex
+	// executions is a bounded ring of the most recent director test runs,
+	// oldest first. It is trimmed down to the configured history size
+	// every time a new execution is recorded.
+	executions []directorTestExecution
+	nextExecID int64
+)
+
+// recordDirectorTestExecution appends a new execution record, trimming the
+// ring down to Origin.DirectorTestHistorySize entries, and returns the
+// record's newly-assigned monotonic id.
+func recordDirectorTestExecution(kind directorTestExecutionKind, directorName, status, message string, startedAt, finishedAt time.Time) int64 {
+	executionsMutex.Lock()
+	defer executionsMutex.Unlock()
+
+	nextExecID++
+	exec := directorTestExecution{
+		ID:           nextExecID,
+		Kind:         kind,
+		DirectorName: directorName,
+		StartedAt:    startedAt,
+		FinishedAt:   finishedAt,
+		Status:       status,
+		Message:      message,
+	}
+	executions = append(executions, exec)
+
+	historySize := param.Origin_DirectorTestHistorySize.GetInt()
+	if historySize <= 0 {
+		historySize = defaultExecutionHistorySize
+	}
+	if len(executions) > historySize {
+		executions = executions[len(executions)-historySize:]
+	}
+	return exec.ID
+}
+
+// listDirectorTestExecutions returns the recorded director test history,
+// optionally filtered by kind and/or a minimum start time, newest first.
+func listDirectorTestExecutions(ctx *gin.Context) {
+	queryParams := listExecutionsRequest{}
+	if err := ctx.ShouldBindQuery(&queryParams); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Invalid query parameters",
+		})
+		return
+	}
+
+	var since time.Time
+	if queryParams.Since != "" {
+		parsed, err := time.Parse(time.RFC3339, queryParams.Since)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "'since' must be an RFC3339 timestamp",
+			})
+			return
+		}
+		since = parsed
+	}
+
+	executionsMutex.RLock()
+	filtered := make([]directorTestExecution, 0, len(executions))
+	for i := len(executions) - 1; i >= 0; i-- {
+		exec := executions[i]
+		if queryParams.Kind != "" && string(exec.Kind) != queryParams.Kind {
+			continue
+		}
+		if !since.IsZero() && exec.StartedAt.Before(since) {
+			continue
+		}
+		filtered = append(filtered, exec)
+	}
+	executionsMutex.RUnlock()
+
+	total := len(filtered)
+	offset := queryParams.Offset
+	if offset < 0 || offset > total {
+		offset = total
+	}
+	limit := queryParams.Limit
+	if limit <= 0 || limit > total-offset {
+		limit = total - offset
+	}
+
+	ctx.JSON(http.StatusOK, listExecutionsResponse{
+		Executions: filtered[offset : offset+limit],
+		Total:      total,
+	})
+}
+
+// getDirectorTestExecution returns a single execution record by its id.
+func getDirectorTestExecution(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "'id' must be an integer",
+		})
+		return
+	}
+
+	executionsMutex.RLock()
+	defer executionsMutex.RUnlock()
+	for _, exec := range executions {
+		if exec.ID == id {
+			ctx.JSON(http.StatusOK, exec)
+			return
+		}
+	}
+	ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+		Status: server_structs.RespFailed,
+		Msg:    "No execution found with the given id",
+	})
+}
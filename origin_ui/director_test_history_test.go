@@ -0,0 +1,112 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package origin_ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetExecutionHistory(t *testing.T) {
+	t.Helper()
+	executionsMutex.Lock()
+	executions = nil
+	nextExecID = 0
+	executionsMutex.Unlock()
+}
+
+func TestListDirectorTestExecutions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resetExecutionHistory(t)
+
+	now := time.Now()
+	recordDirectorTestExecution(executionKindScheduled, "director-a", "ok", "", now, now)
+	recordDirectorTestExecution(executionKindManual, "director-a", "error", "timed out", now.Add(time.Second), now.Add(time.Second))
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/directorTest/executions", nil)
+
+	listDirectorTestExecutions(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp listExecutionsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, 2, resp.Total)
+	// Newest first.
+	assert.Equal(t, executionKindManual, resp.Executions[0].Kind)
+	assert.Equal(t, "error", resp.Executions[0].Status)
+}
+
+func TestListDirectorTestExecutionsFiltersByKind(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resetExecutionHistory(t)
+
+	now := time.Now()
+	recordDirectorTestExecution(executionKindScheduled, "director-a", "ok", "", now, now)
+	recordDirectorTestExecution(executionKindManual, "director-a", "ok", "", now, now)
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/directorTest/executions?kind=manual", nil)
+
+	listDirectorTestExecutions(ctx)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp listExecutionsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, 1, resp.Total)
+	assert.Equal(t, executionKindManual, resp.Executions[0].Kind)
+}
+
+func TestGetDirectorTestExecution(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resetExecutionHistory(t)
+
+	now := time.Now()
+	id := recordDirectorTestExecution(executionKindScheduled, "director-a", "ok", "", now, now)
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "not-a-number"}}
+	getDirectorTestExecution(ctx)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "9999999"}}
+	getDirectorTestExecution(ctx)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(w)
+	ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+	getDirectorTestExecution(ctx)
+	require.Equal(t, http.StatusOK, w.Code)
+	var exec directorTestExecution
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &exec))
+	assert.Equal(t, id, exec.ID)
+}
@@ -19,140 +19,201 @@
 package origin_ui
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/pelicanplatform/pelican/director"
+	"github.com/pelicanplatform/pelican/health_test_auth"
 	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/web_ui"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 type (
 	DirectorTest struct {
-		Status    string `json:"status"`
-		Message   string `json:"message"`
-		Timestamp string `json:"timestamp"`
+		Status       string `json:"status"`
+		Message      string `json:"message"`
+		Timestamp    string `json:"timestamp"`
+		Kind         string `json:"kind"` // "scheduled" or "manual"; defaults to "scheduled" when omitted
+		DirectorName string `json:"directorName"`
+	}
+
+	directorTestResponseBody struct {
+		server_structs.SimpleApiResp
+		ExecutionID int64 `json:"executionId"`
 	}
 )
 
 var (
-	// Mutex for safe concurrent access to the timer
-	timerMutex sync.Mutex
-	// Timer for tracking timeout
-	directorTimeoutTimer *time.Timer
-	// Duration to wait before timeout
-	// TODO: Do we want to make this a configurable value?
+	// Default duration to wait for a director report before counting a miss,
+	// overridden per-call by Origin.DirectorTestTimeout (see directorTestTimeout).
 	directorTimeoutDuration = 30 * time.Second
 	exitSignals             = make(chan os.Signal, 1)
-	exitLoop                = make(chan struct{})
 )
 
-func apiAuthHandler(ctx *gin.Context) {
-	authHeader := ctx.Request.Header.Get("Authorization")
+// directorTestTLSCfg holds the configured Origin.DirectorTestAuth mode and,
+// for mtls/either, the CA bundle and expected director hostname used to
+// verify a presented client certificate. It's populated once in
+// ConfigureOriginAPI.
+var directorTestTLSCfg *health_test_auth.TLSCfg
 
-	// Check if the Authorization header was provided
+// verifyBearerAuth checks the Authorization header for a director-signed
+// bearer token. It aborts the request itself on failure; ok reports
+// whether the caller should proceed to ctx.Next().
+func verifyBearerAuth(ctx *gin.Context) (ok bool) {
+	authHeader := ctx.Request.Header.Get("Authorization")
 	if authHeader == "" {
-		// Use AbortWithStatusJSON to stop invoking the next chain
 		ctx.AbortWithStatusJSON(401, gin.H{"error": "Authorization header is missing"})
-		return
+		return false
 	}
-
-	// Check if the Authorization type is Bearer
 	if !strings.HasPrefix(authHeader, "Bearer ") {
 		ctx.AbortWithStatusJSON(401, gin.H{"error": "Authorization header is not Bearer type"})
-		return
+		return false
 	}
 
-	// Extract the token from the Authorization header
 	token := strings.TrimPrefix(authHeader, "Bearer ")
 	valid, err := director.VerifyDirectorTestReportToken(token)
-
 	if err != nil {
 		log.Warningln(fmt.Sprintf("Error when verifying Bearer token: %s", err))
 		ctx.AbortWithStatusJSON(401, gin.H{"error": fmt.Sprintf("Error when verifying Bearer token: %s", err)})
-		return
+		return false
 	}
-
 	if !valid {
 		log.Warningln("Can't validate Bearer token")
 		ctx.AbortWithStatusJSON(401, gin.H{"error": "Can't validate Bearer token"})
-		return
+		return false
 	}
-	ctx.Next()
+	ctx.Set("directorName", directorNameFromToken(token))
+	return true
 }
 
-// Reset the timer safely
-func resetDirectorTimeoutTimer() {
-	timerMutex.Lock()
-	defer timerMutex.Unlock()
-
-	if directorTimeoutTimer == nil {
-		directorTimeoutTimer = time.NewTimer(directorTimeoutDuration)
-		go func() {
-			for {
-				select {
-				case <-directorTimeoutTimer.C:
-					// Timer fired because no message was received in time.
-					log.Warningln("No director test report received within the time limit")
-					if err := metrics.SetComponentHealthStatus("director", "critical", "No director test report received within the time limit"); err != nil {
-						log.Errorln("Failed to update director component health status:", err)
-					}
-					// Reset the timer for the next period.
-					timerMutex.Lock()
-					directorTimeoutTimer.Reset(directorTimeoutDuration)
-					timerMutex.Unlock()
-				case <-exitLoop:
-					log.Infoln("Gracefully terminating the director-health test timeout loop...")
-					return
-				}
+// directorNameFromToken pulls the director's identity out of an
+// already-verified bearer token's issuer claim, falling back to its
+// subject. The token's signature was already checked by
+// director.VerifyDirectorTestReportToken, so this just reads the claims.
+func directorNameFromToken(token string) string {
+	parsed, err := jwt.ParseInsecure([]byte(token))
+	if err != nil {
+		log.Warningln("Failed to parse claims out of an already-verified director token:", err)
+		return "unknown"
+	}
+	if parsed.Issuer() != "" {
+		return parsed.Issuer()
+	}
+	if parsed.Subject() != "" {
+		return parsed.Subject()
+	}
+	return "unknown"
+}
+
+// verifyMTLSAuth checks that the TLS connection presented a client
+// certificate whose SAN/CN is pinned to the director's advertised
+// hostname. The handshake itself (via directorTestTLSCfg.GetTLSConfig)
+// is responsible for verifying the certificate chains to a trusted CA;
+// this only pins the identity.
+func verifyMTLSAuth(ctx *gin.Context) (ok bool) {
+	if ctx.Request.TLS == nil || len(ctx.Request.TLS.PeerCertificates) == 0 {
+		ctx.AbortWithStatusJSON(401, gin.H{"error": "No client certificate was presented"})
+		return false
+	}
+	if err := directorTestTLSCfg.VerifyPeerHostname(ctx.Request.TLS.PeerCertificates); err != nil {
+		log.Warningln("Rejecting director mTLS callback:", err)
+		ctx.AbortWithStatusJSON(401, gin.H{"error": err.Error()})
+		return false
+	}
+	ctx.Set("directorName", ctx.Request.TLS.PeerCertificates[0].Subject.CommonName)
+	return true
+}
+
+func apiAuthHandler(ctx *gin.Context) {
+	authType := health_test_auth.AuthTypeBearer
+	if directorTestTLSCfg != nil {
+		authType = directorTestTLSCfg.GetAuthType()
+	}
+
+	switch authType {
+	case health_test_auth.AuthTypeMTLS:
+		if verifyMTLSAuth(ctx) {
+			ctx.Next()
+		}
+	case health_test_auth.AuthTypeEither:
+		// Prefer mTLS when a certificate is already on the wire; fall back
+		// to bearer so clusters can roll mTLS out without a flag day.
+		if ctx.Request.TLS != nil && len(ctx.Request.TLS.PeerCertificates) > 0 {
+			if verifyMTLSAuth(ctx) {
+				ctx.Next()
 			}
-		}()
-	} else {
-		if !directorTimeoutTimer.Stop() {
-			<-directorTimeoutTimer.C
+			return
+		}
+		if verifyBearerAuth(ctx) {
+			ctx.Next()
+		}
+	default:
+		if verifyBearerAuth(ctx) {
+			ctx.Next()
 		}
-		directorTimeoutTimer.Reset(directorTimeoutDuration)
 	}
 }
 
 // Director will periodiclly upload/download files to/from all connected
 // origins and test the health status of origins. It will send a request
-// reporting such status to this endpoint, and we will update origin internal
-// health status metric to reflect the director connection status.
+// reporting such status to this endpoint, keyed by the director's verified
+// identity, and we fold the outcome into that director's rolling window to
+// (re)derive the overall director-component health.
 func directorTestResponse(ctx *gin.Context) {
+	startedAt := time.Now()
 	dt := DirectorTest{}
 	if err := ctx.ShouldBind(&dt); err != nil {
 		log.Errorf("Invalid director test response")
 		ctx.JSON(400, gin.H{"error": "Invalid director test response"})
 		return
 	}
-	// We will let the timer go timeout if director didn't send a valid json request
-	resetDirectorTimeoutTimer()
-	if dt.Status == "ok" {
-		if err := metrics.SetComponentHealthStatus("director", "ok", fmt.Sprintf("Director timestamp: %s", dt.Timestamp)); err != nil {
-			log.Errorln("Failed to update director component health status:", err)
-			ctx.JSON(500, gin.H{"error": fmt.Sprintf("Failed to update director component health status: %s", err)})
-			return
-		}
-	} else if dt.Status == "error" {
-		if err := metrics.SetComponentHealthStatus("director", "critical", dt.Message); err != nil {
-			log.Errorln("Failed to update director component health status:", err)
-			ctx.JSON(500, gin.H{"error": fmt.Sprintf("Failed to update director component health status: %s", err)})
-			return
-		}
-	} else {
+	kind := directorTestExecutionKind(dt.Kind)
+	if kind != executionKindManual {
+		kind = executionKindScheduled
+	}
+	directorName := ctx.GetString("directorName")
+	if directorName == "" {
+		directorName = "unknown"
+	}
+
+	if dt.Status != "ok" && dt.Status != "error" {
 		log.Errorf("Invalid director test response, status: %s", dt.Status)
 		ctx.JSON(400, gin.H{"error": fmt.Sprintf("Invalid director test response status: %s", dt.Status)})
+		return
 	}
+
+	// recordDirectorOutcome handles resetting directorName's per-director
+	// timeout timer, so we let that timer go timeout if the director stops
+	// reporting entirely.
+	recordDirectorOutcome(directorName, dt.Status == "ok", dt.Status, dt.Message)
+
+	execID := recordDirectorTestExecution(kind, directorName, dt.Status, dt.Message, startedAt, time.Now())
+	ctx.JSON(http.StatusOK, directorTestResponseBody{
+		SimpleApiResp: server_structs.SimpleApiResp{Status: server_structs.RespOK, Msg: "success"},
+		ExecutionID:   execID,
+	})
 }
 
+// ConfigureOriginAPI registers the origin's director-facing API routes on
+// router and resolves the configured Origin.DirectorTestAuth mode. Callers
+// whose HTTPS listener needs to actually enforce mtls/either (requesting or
+// requiring a client certificate at handshake time) must also call
+// ApplyDirectorTestClientAuth on the *tls.Config the listener will serve
+// with, after this has run - otherwise verifyMTLSAuth would never see a
+// peer certificate to check.
 func ConfigureOriginAPI(router *gin.Engine) error {
 	if router == nil {
 		return errors.New("Origin configuration passed a nil pointer")
@@ -160,29 +221,67 @@ func ConfigureOriginAPI(router *gin.Engine) error {
 	if err := metrics.SetComponentHealthStatus("director", "warning", "Initializing origin, unknown status for director"); err != nil {
 		return err
 	}
-	// start the timer for the director test report timeout
-	resetDirectorTimeoutTimer()
+
+	authType := health_test_auth.AuthType(param.Origin_DirectorTestAuth.GetString())
+	if authType == "" {
+		authType = health_test_auth.AuthTypeBearer
+	}
+	directorHostname := param.Federation_DirectorUrl.GetString()
+	if parsed, err := url.Parse(directorHostname); err == nil && parsed.Hostname() != "" {
+		directorHostname = parsed.Hostname()
+	}
+	directorTestTLSCfg = health_test_auth.NewTLSCfg(
+		authType,
+		param.Origin_DirectorTestCABundle.GetString(),
+		directorHostname,
+	)
 
 	// When program exits
 	signal.Notify(exitSignals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
 	go func() {
-		// Gracefully stop the timer at the exit of the program
+		// Gracefully stop every per-director timeout timer at exit
 		<-exitSignals
-		timerMutex.Lock()
-		defer timerMutex.Unlock()
-		log.Infoln("Gracefully stopping the director-health test timeout timer...")
-		// Terminate the infinite loop to reset the timer
-		close(exitLoop)
-		if directorTimeoutTimer != nil {
-			directorTimeoutTimer.Stop()
-			directorTimeoutTimer = nil
+		log.Infoln("Gracefully stopping the director-health test timeout timers...")
+		directorHealthMutex.Lock()
+		defer directorHealthMutex.Unlock()
+		for _, rec := range directorHealth {
+			if rec.timer != nil {
+				rec.timer.Stop()
+			}
 		}
 	}()
 
 	group := router.Group("/api/v1.0/origin-api")
-	group.Use(apiAuthHandler)
-	group.POST("/directorTest", directorTestResponse)
+	// Only the director's own report uses director auth (bearer/mtls); the
+	// read endpoints below are for the admin UI to poll, so they use the
+	// same admin session auth as the rest of the origin's web UI.
+	group.POST("/directorTest", apiAuthHandler, directorTestResponse)
+	group.GET("/directorTest/executions", web_ui.AuthHandler, web_ui.AdminAuthHandler, listDirectorTestExecutions)
+	group.GET("/directorTest/executions/:id", web_ui.AuthHandler, web_ui.AdminAuthHandler, getDirectorTestExecution)
+	group.GET("/directorTest/status", web_ui.AuthHandler, web_ui.AdminAuthHandler, directorTestStatus)
+
+	return nil
+}
 
+// ApplyDirectorTestClientAuth mutates tlsConfig in place so the origin's
+// real HTTPS listener requests (AuthTypeEither) or requires (AuthTypeMTLS)
+// a client certificate at handshake time, verified against the CA bundle
+// configured via Origin.DirectorTestCABundle. It's a no-op for
+// Origin.DirectorTestAuth=bearer (the default), where tlsConfig is left
+// untouched. Must be called after ConfigureOriginAPI has populated
+// directorTestTLSCfg, and before the listener starts accepting connections.
+func ApplyDirectorTestClientAuth(tlsConfig *tls.Config) error {
+	if directorTestTLSCfg == nil || tlsConfig == nil {
+		return nil
+	}
+	clientCfg, err := directorTestTLSCfg.GetTLSConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to configure director-test mTLS")
+	}
+	if clientCfg != nil {
+		tlsConfig.ClientCAs = clientCfg.ClientCAs
+		tlsConfig.ClientAuth = clientCfg.ClientAuth
+	}
 	return nil
 }